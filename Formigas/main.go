@@ -1,55 +1,66 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"math"
 	"math/rand"
-	"os"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
-)
 
-type Point struct {
-	x, y float64
-}
+	"github.com/IcaroOm/TSP_algoritimo_genetico_golang/localsearch"
+	"github.com/IcaroOm/TSP_algoritimo_genetico_golang/tsplib"
+)
 
 type Route struct {
 	path     []int
 	distance float64
 }
 
-type DistanceMatrix [][]float64
+type DistanceMatrix = tsplib.DistanceMatrix
 
-func (dm DistanceMatrix) Distance(i, j int) float64 {
-	return dm[i][j]
-}
+type Variant string
+
+const (
+	VariantAS   Variant = "as"
+	VariantMMAS Variant = "mmas"
+)
 
 type ACOParams struct {
-	numAnts    int
-	alpha      float64
-	beta       float64
-	rho        float64
-	q          float64
-	iterations int
-	eliteAnts  int
+	numAnts     int
+	alpha       float64
+	beta        float64
+	rho         float64
+	q           float64
+	iterations  int
+	eliteAnts   int
+	twoOpt      bool
+	lsStrategy  localsearch.Strategy
+	lsMaxPasses int
+	lsSegLen    int
+	lsLKDepth   int
+	seed        int64
+	variant     Variant
+	pBest       float64
+	stagnation  int
 }
 
 type PheromoneMatrix [][]float64
 
-func createDistanceMatrix(points []Point) DistanceMatrix {
-	n := len(points)
-	matrix := make(DistanceMatrix, n)
-	for i := range matrix {
-		matrix[i] = make([]float64, n)
-		for j := range matrix[i] {
-			matrix[i][j] = math.Hypot(points[i].x-points[j].x, points[i].y-points[j].y)
-		}
-	}
-	return matrix
+// mmasMinBranching is the branching-factor floor at or below which MMAS considers
+// the colony converged and reinitializes pm to tauMax; 2.0 means ants are
+// effectively down to a single candidate edge per city.
+const mmasMinBranching = 2.0
+
+// deriveRand produces a per-worker RNG from a base seed and worker id, so
+// concurrent ant construction is reproducible regardless of GOMAXPROCS or
+// goroutine scheduling order.
+func deriveRand(seed int64, workerID int) *rand.Rand {
+	h := uint64(seed) + uint64(workerID)*0x9E3779B97F4A7C15
+	h = (h ^ (h >> 30)) * 0xBF58476D1CE4E5B9
+	h = (h ^ (h >> 27)) * 0x94D049BB133111EB
+	h ^= h >> 31
+	return rand.New(rand.NewSource(int64(h)))
 }
 
 func initializePheromones(size int, initial float64) PheromoneMatrix {
@@ -74,7 +85,7 @@ func calculateRouteDistance(path []int, dm DistanceMatrix) float64 {
 	return distance
 }
 
-func selectNextCity(current int, visited []bool, dm DistanceMatrix, pm PheromoneMatrix, params ACOParams) int {
+func selectNextCity(current int, visited []bool, dm DistanceMatrix, pm PheromoneMatrix, params ACOParams, rng *rand.Rand) int {
 	var probabilities []float64
 	var cities []int
 	total := 0.0
@@ -91,10 +102,10 @@ func selectNextCity(current int, visited []bool, dm DistanceMatrix, pm Pheromone
 	}
 
 	if total == 0 {
-		return cities[rand.Intn(len(cities))]
+		return cities[rng.Intn(len(cities))]
 	}
 
-	r := rand.Float64() * total
+	r := rng.Float64() * total
 	cumulative := 0.0
 	for i, p := range probabilities {
 		cumulative += p
@@ -105,18 +116,18 @@ func selectNextCity(current int, visited []bool, dm DistanceMatrix, pm Pheromone
 	return cities[len(cities)-1]
 }
 
-func constructAntRoute(dm DistanceMatrix, pm PheromoneMatrix, params ACOParams) Route {
+func constructAntRoute(dm DistanceMatrix, pm PheromoneMatrix, params ACOParams, rng *rand.Rand) Route {
 	size := len(dm)
 	path := make([]int, size)
 	visited := make([]bool, size)
 
-	start := rand.Intn(size)
+	start := rng.Intn(size)
 	path[0] = start
 	visited[start] = true
 
 	for i := 1; i < size; i++ {
 		current := path[i-1]
-		next := selectNextCity(current, visited, dm, pm, params)
+		next := selectNextCity(current, visited, dm, pm, params, rng)
 		path[i] = next
 		visited[next] = true
 	}
@@ -128,63 +139,126 @@ func constructAntRoute(dm DistanceMatrix, pm PheromoneMatrix, params ACOParams)
 }
 
 func sortRoutes(ants []Route) {
-    if len(ants) < 2 {
-        return
-    }
-
-    left, right := 0, len(ants)-1
-    pivot := ants[len(ants)/2].distance
-
-    for left <= right {
-        for ants[left].distance < pivot {
-            left++
-        }
-        for ants[right].distance > pivot {
-            right--
-        }
-        if left <= right {
-            ants[left], ants[right] = ants[right], ants[left]
-            left++
-            right--
-        }
-    }
-
-    if right > 0 {
-        sortRoutes(ants[:right+1])
-    }
-    if left < len(ants) {
-        sortRoutes(ants[left:])
-    }
+	if len(ants) < 2 {
+		return
+	}
+
+	left, right := 0, len(ants)-1
+	pivot := ants[len(ants)/2].distance
+
+	for left <= right {
+		for ants[left].distance < pivot {
+			left++
+		}
+		for ants[right].distance > pivot {
+			right--
+		}
+		if left <= right {
+			ants[left], ants[right] = ants[right], ants[left]
+			left++
+			right--
+		}
+	}
+
+	if right > 0 {
+		sortRoutes(ants[:right+1])
+	}
+	if left < len(ants) {
+		sortRoutes(ants[left:])
+	}
+}
+
+func depositOnEdges(pm PheromoneMatrix, route Route, deposit float64) {
+	for i := 0; i < len(route.path); i++ {
+		from := route.path[i]
+		to := route.path[(i+1)%len(route.path)]
+		pm[from][to] += deposit
+		pm[to][from] += deposit
+	}
 }
 
-func updatePheromones(pm PheromoneMatrix, ants []Route, params ACOParams) {
-    for i := range pm {
-        for j := range pm[i] {
-            pm[i][j] *= (1.0 - params.rho)
-        }
-    }
-
-    sortRoutes(ants)
-
-    eliteCount := params.eliteAnts
-    if eliteCount > len(ants) {
-        eliteCount = len(ants)
-    }
-    
-    for _, ant := range ants[:eliteCount] {
-        deposit := params.q / ant.distance
-        for i := 0; i < len(ant.path); i++ {
-            from := ant.path[i]
-            to := ant.path[(i+1)%len(ant.path)]
-            pm[from][to] += deposit
-            pm[to][from] += deposit
-        }
-    }
+// mmasBounds derives the MMAS pheromone trail bounds from the best-so-far
+// tour length, following Stutzle & Hoos: tauMax keeps the best edges from
+// growing without limit, and tauMin (expressed via the probability p_best
+// that a trail converges to the best tour, assuming an average of n/2
+// choices per city) keeps every edge reachable so the colony never fully
+// converges.
+func mmasBounds(n int, rho, bestLen, pBest float64) (tauMin, tauMax float64) {
+	tauMax = 1.0 / (rho * bestLen)
+	avg := float64(n) / 2.0
+	root := math.Pow(pBest, 1.0/float64(n))
+	tauMin = tauMax * (1 - root) / ((avg - 1) * root)
+	return tauMin, tauMax
+}
+
+func clampPheromones(pm PheromoneMatrix, tauMin, tauMax float64) {
+	for i := range pm {
+		for j := range pm[i] {
+			if i == j {
+				continue
+			}
+			switch {
+			case pm[i][j] < tauMin:
+				pm[i][j] = tauMin
+			case pm[i][j] > tauMax:
+				pm[i][j] = tauMax
+			}
+		}
+	}
+}
+
+// branchingFactor returns the average number of edges per city whose
+// pheromone is at least threshold, the usual MMAS proxy for how converged
+// the colony is: a value near 2 means ants are essentially following a
+// single tour.
+func branchingFactor(pm PheromoneMatrix, threshold float64) float64 {
+	n := len(pm)
+	count := 0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i != j && pm[i][j] >= threshold {
+				count++
+			}
+		}
+	}
+	return float64(count) / float64(n)
+}
+
+func updatePheromones(pm PheromoneMatrix, ants []Route, params ACOParams, bestSoFar Route, iter int) {
+	for i := range pm {
+		for j := range pm[i] {
+			pm[i][j] *= (1.0 - params.rho)
+		}
+	}
+
+	sortRoutes(ants)
+
+	if params.variant == VariantMMAS {
+		depositor := ants[0]
+		if iter%2 == 1 {
+			depositor = bestSoFar
+		}
+		depositOnEdges(pm, depositor, params.q/depositor.distance)
+
+		tauMin, tauMax := mmasBounds(len(pm), params.rho, bestSoFar.distance, params.pBest)
+		clampPheromones(pm, tauMin, tauMax)
+		return
+	}
+
+	eliteCount := params.eliteAnts
+	if eliteCount > len(ants) {
+		eliteCount = len(ants)
+	}
+
+	for _, ant := range ants[:eliteCount] {
+		depositOnEdges(pm, ant, params.q/ant.distance)
+	}
 }
 
 func aco(dm DistanceMatrix, params ACOParams) Route {
 	pm := initializePheromones(len(dm), 1.0)
 	bestRoute := Route{distance: math.MaxFloat64}
+	stagnantFor := 0
 
 	for iter := 0; iter < params.iterations; iter++ {
 		var wg sync.WaitGroup
@@ -194,84 +268,55 @@ func aco(dm DistanceMatrix, params ACOParams) Route {
 			wg.Add(1)
 			go func(idx int) {
 				defer wg.Done()
-				ants[idx] = constructAntRoute(dm, pm, params)
+				rng := deriveRand(params.seed, iter*params.numAnts+idx)
+				ants[idx] = constructAntRoute(dm, pm, params, rng)
 			}(i)
 		}
 		wg.Wait()
 
-		for _, ant := range ants {
-			if ant.distance < bestRoute.distance {
-				bestRoute = ant
+		iterBest := 0
+		for i, ant := range ants {
+			if ant.distance < ants[iterBest].distance {
+				iterBest = i
 			}
 		}
 
-		updatePheromones(pm, ants, params)
-
-		if iter%10 == 0 {
-			fmt.Printf("Iteration %d: Best = %.2f\n", iter, bestRoute.distance)
-		}
-	}
-
-	return bestRoute
-}
-
-func readPointsFromFile(filename string) ([]Point, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	var dimension int
-	var points []Point
-	inCoordSection := false
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+		if params.twoOpt {
+			refined := localsearch.Apply(
+				localsearch.Route{Path: ants[iterBest].path, Distance: ants[iterBest].distance},
+				dm, params.lsStrategy, params.lsMaxPasses, params.lsSegLen, params.lsLKDepth,
+			)
+			ants[iterBest] = Route{path: refined.Path, distance: refined.Distance}
 		}
 
-		if strings.HasPrefix(line, "DIMENSION") {
-			parts := strings.Split(line, ":")
-			if len(parts) < 2 {
-				parts = strings.Split(line, " ")
-			}
-			dimStr := strings.TrimSpace(parts[1])
-			dimension, _ = strconv.Atoi(dimStr)
-			points = make([]Point, dimension)
+		if ants[iterBest].distance < bestRoute.distance-1e-9 {
+			bestRoute = ants[iterBest]
+			stagnantFor = 0
+		} else {
+			stagnantFor++
 		}
 
-		if strings.HasPrefix(line, "NODE_COORD_SECTION") {
-			inCoordSection = true
-			continue
-		}
+		updatePheromones(pm, ants, params, bestRoute, iter)
 
-		if inCoordSection && strings.HasPrefix(line, "EOF") {
-			break
+		if params.variant == VariantMMAS && params.stagnation > 0 {
+			tauMin, tauMax := mmasBounds(len(pm), params.rho, bestRoute.distance, params.pBest)
+			branching := branchingFactor(pm, (tauMin+tauMax)/2)
+			if stagnantFor >= params.stagnation || branching <= mmasMinBranching {
+				pm = initializePheromones(len(pm), tauMax)
+				stagnantFor = 0
+			}
 		}
 
-		if inCoordSection {
-			parts := strings.Fields(line)
-			if len(parts) < 3 {
-				continue
-			}
-			idx, _ := strconv.Atoi(parts[0])
-			x, _ := strconv.ParseFloat(parts[1], 64)
-			y, _ := strconv.ParseFloat(parts[2], 64)
-			if idx >= 1 && idx <= dimension {
-				points[idx-1] = Point{x, y}
-			}
+		if iter%10 == 0 {
+			fmt.Printf("Iteration %d: Best = %.2f\n", iter, bestRoute.distance)
 		}
 	}
 
-	return points, nil
+	return bestRoute
 }
 
 func main() {
 	startTime := time.Now()
-	rand.Seed(time.Now().UnixNano())
 
 	var (
 		inputFile   = flag.String("input", "", "TSPLIB file")
@@ -282,33 +327,72 @@ func main() {
 		q           = flag.Float64("q", 100.0, "Pheromone quantity")
 		iterations  = flag.Int("iters", 100, "ACO iterations")
 		eliteAnts   = flag.Int("elite", 50, "Number of elite ants")
+		twoOpt      = flag.Bool("twoopt", false, "Refine each iteration's best ant with local search")
+		lsStrategy  = flag.String("ls-strategy", "all", "Local search strategy when -twoopt is set: none|2opt|oropt|lk|both|all")
+		lsMaxPasses = flag.Int("ls-maxpasses", 2, "2-opt: maximum number of passes (stops early after a pass with no improvement)")
+		lsSegLen    = flag.Int("ls-seglen", 3, "Or-opt: length of the relocated city segment")
+		lsLKDepth   = flag.Int("ls-lkdepth", 5, "Lin-Kernighan: maximum chained-move depth")
+		seed        = flag.Int64("seed", 0, "RNG seed (0 = time-based)")
+		variant     = flag.String("variant", "as", "ACO variant: as|mmas")
+		pBest       = flag.Float64("pbest", 0.05, "MMAS: probability a trail converges to the best tour, used to derive tauMin")
+		stagnation  = flag.Int("stagnation", 20, "MMAS: iterations without improvement (or low branching factor) before reinitializing pheromones")
 	)
 	flag.Parse()
 
-	var points []Point
-	var err error
+	aoVariant := Variant(*variant)
+	if aoVariant != VariantAS && aoVariant != VariantMMAS {
+		fmt.Println("Unknown -variant:", *variant, "(expected as|mmas)")
+		return
+	}
+
+	lsStrat, err := localsearch.ParseStrategy(*lsStrategy)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	effectiveSeed := *seed
+	if effectiveSeed == 0 {
+		effectiveSeed = time.Now().UnixNano()
+	}
+	fmt.Printf("Using seed: %d\n", effectiveSeed)
+
+	var instance *tsplib.TSPInstance
 
 	switch {
 	case *inputFile != "":
-		points, err = readPointsFromFile(*inputFile)
+		instance, err = tsplib.ReadInstance(*inputFile)
 		if err != nil {
 			fmt.Println("Error reading file:", err)
 			return
 		}
+		if err := instance.Validate(); err != nil {
+			fmt.Println("Invalid TSPLIB instance:", err)
+			return
+		}
 	default:
 		fmt.Println("No input specified")
 		return
 	}
 
-	dm := createDistanceMatrix(points)
+	dm := tsplib.BuildDistanceMatrix(instance)
 	params := ACOParams{
-		numAnts:    *numAnts,
-		alpha:      *alpha,
-		beta:       *beta,
-		rho:        *rho,
-		q:          *q,
-		iterations: *iterations,
-		eliteAnts:  *eliteAnts,
+		numAnts:     *numAnts,
+		alpha:       *alpha,
+		beta:        *beta,
+		rho:         *rho,
+		q:           *q,
+		iterations:  *iterations,
+		eliteAnts:   *eliteAnts,
+		twoOpt:      *twoOpt,
+		lsStrategy:  lsStrat,
+		lsMaxPasses: *lsMaxPasses,
+		lsSegLen:    *lsSegLen,
+		lsLKDepth:   *lsLKDepth,
+		seed:        effectiveSeed,
+		variant:     aoVariant,
+		pBest:       *pBest,
+		stagnation:  *stagnation,
 	}
 
 	fmt.Println("Running ACO...")
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+type Topology string
+
+const (
+	TopologyRing      Topology = "ring"
+	TopologyRandom    Topology = "random"
+	TopologyBroadcast Topology = "broadcast"
+)
+
+type islandsConfig struct {
+	numIslands        int
+	migrationInterval int
+	migrationSize     int
+	topology          Topology
+}
+
+// migration exchanges the top migrationSize individuals between islands
+// every migrationInterval generations over buffered channels, following the
+// configured topology. Sends are non-blocking so a slow or full neighbor
+// never stalls the sender's generation loop.
+type migration struct {
+	id       int
+	inbox    chan []Route
+	outboxes []chan []Route
+	cfg      islandsConfig
+}
+
+func (m *migration) exchange(gen int, population *[]Route, rng *rand.Rand) {
+	if m.cfg.migrationInterval <= 0 || gen == 0 || gen%m.cfg.migrationInterval != 0 {
+		return
+	}
+
+	pop := *population
+	sort.Slice(pop, func(i, j int) bool { return pop[i].distance < pop[j].distance })
+
+	size := m.cfg.migrationSize
+	if size > len(pop) {
+		size = len(pop)
+	}
+	emigrants := append([]Route(nil), pop[:size]...)
+	m.send(emigrants, rng)
+
+	// Drain every immigrant batch waiting in the inbox (broadcast can have
+	// one from each other island) rather than just the first, replacing the
+	// worst individuals with them in arrival order.
+	replaced := 0
+	for replaced < len(pop) {
+		select {
+		case immigrants := <-m.inbox:
+			for _, r := range immigrants {
+				if replaced >= len(pop) {
+					break
+				}
+				pop[len(pop)-1-replaced] = r
+				replaced++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (m *migration) send(emigrants []Route, rng *rand.Rand) {
+	switch m.cfg.topology {
+	case TopologyRandom:
+		if len(m.outboxes) > 1 {
+			to := rng.Intn(len(m.outboxes) - 1)
+			if to >= m.id {
+				to++
+			}
+			trySend(m.outboxes[to], emigrants)
+		}
+	case TopologyBroadcast:
+		for to, outbox := range m.outboxes {
+			if to != m.id {
+				trySend(outbox, emigrants)
+			}
+		}
+	default: // ring
+		to := (m.id + 1) % len(m.outboxes)
+		trySend(m.outboxes[to], emigrants)
+	}
+}
+
+func trySend(ch chan []Route, emigrants []Route) {
+	select {
+	case ch <- emigrants:
+	default:
+	}
+}
+
+// runIslands evolves islandsCfg.numIslands independent populations in
+// parallel, each with its own LRU cache and RNG, migrating individuals
+// between them every migrationInterval generations, and returns the best
+// route found across all islands.
+func runIslands(numCities int, dm DistanceMatrix, cfg gaConfig, islandsCfg islandsConfig, baseSeed int64) Route {
+	// Broadcast fans each migration out to every other island in the same
+	// round, so its inboxes need a slot per sender or all but one send would
+	// be dropped by trySend's non-blocking select.
+	inboxSize := 1
+	if islandsCfg.topology == TopologyBroadcast && islandsCfg.numIslands > 1 {
+		inboxSize = islandsCfg.numIslands - 1
+	}
+	inboxes := make([]chan []Route, islandsCfg.numIslands)
+	for i := range inboxes {
+		inboxes[i] = make(chan []Route, inboxSize)
+	}
+
+	bestPerIsland := make([]Route, islandsCfg.numIslands)
+	var wg sync.WaitGroup
+
+	for i := 0; i < islandsCfg.numIslands; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(baseSeed + int64(id)*1_000_003))
+			mig := &migration{id: id, inbox: inboxes[id], outboxes: inboxes, cfg: islandsCfg}
+			bestPerIsland[id] = runPopulation(numCities, dm, cfg, rng, mig, false)
+		}(i)
+	}
+
+	wg.Wait()
+
+	best := bestPerIsland[0]
+	for _, candidate := range bestPerIsland[1:] {
+		if candidate.distance < best.distance {
+			best = candidate
+		}
+	}
+	return best
+}
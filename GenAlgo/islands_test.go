@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTrySendNonBlocking(t *testing.T) {
+	ch := make(chan []Route, 1)
+	trySend(ch, []Route{{distance: 1}})
+	// The inbox is now full; a second trySend must drop instead of blocking.
+	done := make(chan struct{})
+	go func() {
+		trySend(ch, []Route{{distance: 2}})
+		close(done)
+	}()
+	<-done
+
+	if got := <-ch; got[0].distance != 1 {
+		t.Fatalf("expected the first send to survive, got %v", got)
+	}
+}
+
+// TestMigrationExchangeDrainsMultipleBatches guards the broadcast fix: every
+// batch waiting in the inbox should replace a worst individual, not just the
+// first one a plain `select` would have read.
+func TestMigrationExchangeDrainsMultipleBatches(t *testing.T) {
+	inbox := make(chan []Route, 2)
+	other := make(chan []Route, 2)
+	batch1 := []Route{{distance: 10}}
+	batch2 := []Route{{distance: 20}}
+	inbox <- batch1
+	inbox <- batch2
+
+	m := &migration{
+		id:       0,
+		inbox:    inbox,
+		outboxes: []chan []Route{inbox, other},
+		cfg: islandsConfig{
+			migrationInterval: 1,
+			migrationSize:     1,
+			topology:          TopologyRing,
+		},
+	}
+
+	pop := []Route{{distance: 1}, {distance: 2}, {distance: 3}, {distance: 4}}
+	rng := rand.New(rand.NewSource(1))
+	m.exchange(1, &pop, rng)
+
+	if pop[len(pop)-1].distance != 10 || pop[len(pop)-2].distance != 20 {
+		t.Fatalf("expected both pending batches to replace the worst individuals, got %v", pop)
+	}
+}
@@ -1,35 +1,27 @@
 package main
 
 import (
-	"bufio"
 	"container/list"
 	"flag"
 	"fmt"
-	"math"
 	"math/rand"
-	"os"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-)
 
-type Point struct {
-	x, y float64
-}
+	"github.com/IcaroOm/TSP_algoritimo_genetico_golang/localsearch"
+	"github.com/IcaroOm/TSP_algoritimo_genetico_golang/tsplib"
+)
 
 type Route struct {
 	path     []int
 	distance float64
 }
 
-type DistanceMatrix [][]float64
-
-func (dm DistanceMatrix) Distance(i, j int) float64 {
-	return dm[i][j]
-}
+type DistanceMatrix = tsplib.DistanceMatrix
 
 type LRUCache struct {
 	capacity int
@@ -82,18 +74,6 @@ func (lc *LRUCache) Put(key string, value float64) {
 	lc.cache[key] = elem
 }
 
-func createDistanceMatrix(points []Point) DistanceMatrix {
-	n := len(points)
-	matrix := make(DistanceMatrix, n)
-	for i := range matrix {
-		matrix[i] = make([]float64, n)
-		for j := range matrix[i] {
-			matrix[i][j] = math.Hypot(points[i].x-points[j].x, points[i].y-points[j].y)
-		}
-	}
-	return matrix
-}
-
 func normalizePath(path []int) []int {
 	if len(path) == 0 {
 		return path
@@ -121,10 +101,10 @@ func getKey(path []int) string {
 	return builder.String()
 }
 
-func cachedCrossover(parent1, parent2 Route, cache *LRUCache) (Route, Route) {
+func cachedCrossover(parent1, parent2 Route, cache *LRUCache, rng *rand.Rand) (Route, Route) {
 	maxAttempts := 15
 	for attempt := 0; attempt < maxAttempts; attempt++ {
-		child1, child2 := crossover(parent1, parent2)
+		child1, child2 := crossover(parent1, parent2, rng)
 		key1 := getKey(child1.path)
 		key2 := getKey(child2.path)
 
@@ -134,20 +114,20 @@ func cachedCrossover(parent1, parent2 Route, cache *LRUCache) (Route, Route) {
 			}
 		}
 	}
-	return crossover(parent1, parent2)
+	return crossover(parent1, parent2, rng)
 }
 
-func cachedMutate(route Route, mutationRate float64, cache *LRUCache) Route {
+func cachedMutate(route Route, mutationRate float64, cache *LRUCache, rng *rand.Rand) Route {
 	maxAttempts := 8
 	originalRate := mutationRate
 	for attempt := 0; attempt < maxAttempts; attempt++ {
-		mutated := mutate(route, mutationRate)
+		mutated := mutate(route, mutationRate, rng)
 		if _, exists := cache.Get(getKey(mutated.path)); !exists {
 			return mutated
 		}
 		mutationRate *= 1.5
 	}
-	return mutate(route, originalRate)
+	return mutate(route, originalRate, rng)
 }
 
 func evaluatePopulation(population []Route, dm DistanceMatrix, cache *LRUCache) {
@@ -188,29 +168,29 @@ func calculateDistance(route Route, dm DistanceMatrix) float64 {
 	return total + dm.Distance(prev, 0)
 }
 
-func randomRoute(numCities int) Route {
+func randomRoute(numCities int, rng *rand.Rand) Route {
 	path := make([]int, numCities-1)
 	for i := 1; i < numCities; i++ {
 		path[i-1] = i
 	}
-	rand.Shuffle(len(path), func(i, j int) {
+	rng.Shuffle(len(path), func(i, j int) {
 		path[i], path[j] = path[j], path[i]
 	})
 	return Route{path: path}
 }
 
-func initializePopulation(popSize, numCities int) []Route {
+func initializePopulation(popSize, numCities int, rng *rand.Rand) []Route {
 	population := make([]Route, popSize)
 	for i := 0; i < popSize; i++ {
-		population[i] = randomRoute(numCities)
+		population[i] = randomRoute(numCities, rng)
 	}
 	return population
 }
 
-func tournamentSelection(population []Route, tournamentSize int) Route {
-	best := population[rand.Intn(len(population))]
+func tournamentSelection(population []Route, tournamentSize int, rng *rand.Rand) Route {
+	best := population[rng.Intn(len(population))]
 	for i := 1; i < tournamentSize; i++ {
-		competitor := population[rand.Intn(len(population))]
+		competitor := population[rng.Intn(len(population))]
 		if competitor.distance < best.distance {
 			best = competitor
 		}
@@ -218,9 +198,9 @@ func tournamentSelection(population []Route, tournamentSize int) Route {
 	return best
 }
 
-func crossover(parent1, parent2 Route) (Route, Route) {
+func crossover(parent1, parent2 Route, rng *rand.Rand) (Route, Route) {
 	size := len(parent1.path)
-	a, b := rand.Intn(size), rand.Intn(size)
+	a, b := rng.Intn(size), rng.Intn(size)
 	if a > b {
 		a, b = b, a
 	}
@@ -252,145 +232,201 @@ func crossover(parent1, parent2 Route) (Route, Route) {
 	return Route{path: child1Path}, Route{path: child2Path}
 }
 
-func mutate(route Route, mutationRate float64) Route {
-	if rand.Float64() >= mutationRate {
+func mutate(route Route, mutationRate float64, rng *rand.Rand) Route {
+	if rng.Float64() >= mutationRate {
 		return route
 	}
 	size := len(route.path)
-	swaps := rand.Intn(3) + 1
+	swaps := rng.Intn(3) + 1
 	for i := 0; i < swaps; i++ {
-		a, b := rand.Intn(size), rand.Intn(size)
+		a, b := rng.Intn(size), rng.Intn(size)
 		route.path[a], route.path[b] = route.path[b], route.path[a]
 	}
 	return route
 }
 
-func readPointsFromFile(filename string) ([]Point, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+func toClosedTour(route Route) []int {
+	tour := make([]int, len(route.path)+1)
+	tour[0] = 0
+	copy(tour[1:], route.path)
+	return tour
+}
+
+func fromClosedTour(tour []int, dm DistanceMatrix) Route {
+	zero := 0
+	for i, c := range tour {
+		if c == 0 {
+			zero = i
+			break
+		}
+	}
+	rotated := append(append([]int{}, tour[zero:]...), tour[:zero]...)
+	route := Route{path: append([]int(nil), rotated[1:]...)}
+	route.distance = calculateDistance(route, dm)
+	return route
+}
+
+func refineElites(population []Route, eliteSize int, dm DistanceMatrix, strategy localsearch.Strategy, maxPasses, segLen, lkDepth int) {
+	for i := 0; i < eliteSize && i < len(population); i++ {
+		ls := localsearch.Route{Path: toClosedTour(population[i]), Distance: population[i].distance}
+		refined := localsearch.Apply(ls, dm, strategy, maxPasses, segLen, lkDepth)
+		population[i] = fromClosedTour(refined.Path, dm)
 	}
-	defer file.Close()
+}
+
+type gaConfig struct {
+	popSize      int
+	generations  int
+	tournament   int
+	mutationRate float64
+	eliteSize    int
+	cacheSize    int
+	twoOpt       bool
+	lsStrategy   localsearch.Strategy
+	lsMaxPasses  int
+	lsSegLen     int
+	lsLKDepth    int
+}
+
+// runPopulation evolves a single population for cfg.generations generations,
+// optionally exchanging migrants with other islands through mig, and
+// returns the best route found. When report is true it prints periodic
+// progress, matching the original single-population GA's output.
+func runPopulation(numCities int, dm DistanceMatrix, cfg gaConfig, rng *rand.Rand, mig *migration, report bool) Route {
+	cache := NewLRUCache(cfg.cacheSize)
+	population := initializePopulation(cfg.popSize, numCities, rng)
+	evaluatePopulation(population, dm, cache)
 
-	scanner := bufio.NewScanner(file)
-	var dimension int
-	var points []Point
-	inCoordSection := false
+	best := population[0]
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+	for gen := 0; gen < cfg.generations; gen++ {
+		sort.Slice(population, func(i, j int) bool {
+			return population[i].distance < population[j].distance
+		})
+		if cfg.twoOpt {
+			refineElites(population, cfg.eliteSize, dm, cfg.lsStrategy, cfg.lsMaxPasses, cfg.lsSegLen, cfg.lsLKDepth)
+			sort.Slice(population, func(i, j int) bool {
+				return population[i].distance < population[j].distance
+			})
+		}
+		if population[0].distance < best.distance {
+			best = population[0]
 		}
 
-		if strings.HasPrefix(line, "DIMENSION") {
-			parts := strings.Split(line, ":")
-			if len(parts) < 2 {
-				parts = strings.Split(line, " ")
-			}
-			dimStr := strings.TrimSpace(parts[1])
-			dimension, _ = strconv.Atoi(dimStr)
-			points = make([]Point, dimension)
+		newPopulation := make([]Route, 0, cfg.popSize)
+		if cfg.eliteSize > 0 {
+			newPopulation = append(newPopulation, population[:cfg.eliteSize]...)
 		}
 
-		if strings.HasPrefix(line, "NODE_COORD_SECTION") {
-			inCoordSection = true
-			continue
+		for len(newPopulation) < cfg.popSize {
+			parent1 := tournamentSelection(population, cfg.tournament, rng)
+			parent2 := tournamentSelection(population, cfg.tournament, rng)
+			child1, child2 := cachedCrossover(parent1, parent2, cache, rng)
+			child1 = cachedMutate(child1, cfg.mutationRate, cache, rng)
+			child2 = cachedMutate(child2, cfg.mutationRate, cache, rng)
+			newPopulation = append(newPopulation, child1, child2)
 		}
 
-		if inCoordSection && strings.HasPrefix(line, "EOF") {
-			break
+		newPopulation = newPopulation[:cfg.popSize]
+		evaluatePopulation(newPopulation, dm, cache)
+		population = newPopulation
+
+		if mig != nil {
+			mig.exchange(gen, &population, rng)
 		}
 
-		if inCoordSection {
-			parts := strings.Fields(line)
-			if len(parts) < 3 {
-				continue
-			}
-			idx, _ := strconv.Atoi(parts[0])
-			x, _ := strconv.ParseFloat(parts[1], 64)
-			y, _ := strconv.ParseFloat(parts[2], 64)
-			if idx >= 1 && idx <= dimension {
-				points[idx-1] = Point{x, y}
-			}
+		if report && (gen%50 == 0 || gen == cfg.generations-1) {
+			fmt.Printf("Gen %d: Best = %.2f\n", gen, best.distance)
 		}
 	}
 
-	return points, nil
+	return best
 }
 
 func main() {
 	startTime := time.Now()
-	rand.Seed(time.Now().UnixNano())
 
 	var (
-		inputFile     = flag.String("input", "", "Input file with city coordinates")
-		popSize       = flag.Int("pop", 1000, "Population size")
-		generations   = flag.Int("gens", 2000, "Number of generations")
-		tournament    = flag.Int("tournament", 10, "Tournament size")
-		mutationRate  = flag.Float64("mut", 0.1, "Mutation rate")
-		eliteSize     = flag.Int("elite", 10, "Elite population size")
-		cacheSize     = flag.Int("cache", 10000, "LRU cache size")
+		inputFile       = flag.String("input", "", "Input file with city coordinates")
+		popSize         = flag.Int("pop", 1000, "Population size")
+		generations     = flag.Int("gens", 2000, "Number of generations")
+		tournament      = flag.Int("tournament", 10, "Tournament size")
+		mutationRate    = flag.Float64("mut", 0.1, "Mutation rate")
+		eliteSize       = flag.Int("elite", 10, "Elite population size")
+		cacheSize       = flag.Int("cache", 10000, "LRU cache size")
+		twoOpt          = flag.Bool("twoopt", false, "Refine elites each generation with local search")
+		lsStrategy      = flag.String("ls-strategy", "all", "Local search strategy when -twoopt is set: none|2opt|oropt|lk|both|all")
+		lsMaxPasses     = flag.Int("ls-maxpasses", 2, "2-opt: maximum number of passes (stops early after a pass with no improvement)")
+		lsSegLen        = flag.Int("ls-seglen", 3, "Or-opt: length of the relocated city segment")
+		lsLKDepth       = flag.Int("ls-lkdepth", 5, "Lin-Kernighan: maximum chained-move depth")
+		seed            = flag.Int64("seed", 0, "RNG seed (0 = time-based)")
+		numIslands      = flag.Int("islands", 1, "Number of parallel island sub-populations (1 disables the island model)")
+		migrateInterval = flag.Int("migrate-interval", 50, "Generations between migrations")
+		migrateSize     = flag.Int("migrate-size", 5, "Number of individuals exchanged per migration")
+		topology        = flag.String("topology", "ring", "Migration topology: ring, random, or broadcast")
 	)
 	flag.Parse()
 
-	var points []Point
-	var err error
+	strategy, err := localsearch.ParseStrategy(*lsStrategy)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	effectiveSeed := *seed
+	if effectiveSeed == 0 {
+		effectiveSeed = time.Now().UnixNano()
+	}
+	fmt.Printf("Using seed: %d\n", effectiveSeed)
+	rng := rand.New(rand.NewSource(effectiveSeed))
+
+	var instance *tsplib.TSPInstance
 
 	switch {
 	case *inputFile != "":
-		points, err = readPointsFromFile(*inputFile)
+		instance, err = tsplib.ReadInstance(*inputFile)
 		if err != nil {
 			fmt.Println("Error reading file:", err)
 			return
 		}
+		if err := instance.Validate(); err != nil {
+			fmt.Println("Invalid TSPLIB instance:", err)
+			return
+		}
 	default:
 		fmt.Println("No cities specified. Use -input or -random")
 		return
 	}
 
-	numCities := len(points)
-	dm := createDistanceMatrix(points)
-	cache := NewLRUCache(*cacheSize)
-	population := initializePopulation(*popSize, numCities)
-	evaluatePopulation(population, dm, cache)
-
-	best := population[0]
-	updateBest := func(r Route) {
-		if r.distance < best.distance {
-			best = r
-		}
+	numCities := instance.Dimension
+	dm := tsplib.BuildDistanceMatrix(instance)
+	cfg := gaConfig{
+		popSize:      *popSize,
+		generations:  *generations,
+		tournament:   *tournament,
+		mutationRate: *mutationRate,
+		eliteSize:    *eliteSize,
+		cacheSize:    *cacheSize,
+		twoOpt:       *twoOpt,
+		lsStrategy:   strategy,
+		lsMaxPasses:  *lsMaxPasses,
+		lsSegLen:     *lsSegLen,
+		lsLKDepth:    *lsLKDepth,
 	}
 
-	for gen := 0; gen < *generations; gen++ {
-		sort.Slice(population, func(i, j int) bool {
-			return population[i].distance < population[j].distance
-		})
-		updateBest(population[0])
-
-		newPopulation := make([]Route, 0, *popSize)
-		if *eliteSize > 0 {
-			newPopulation = append(newPopulation, population[:*eliteSize]...)
-		}
-
-		for len(newPopulation) < *popSize {
-			parent1 := tournamentSelection(population, *tournament)
-			parent2 := tournamentSelection(population, *tournament)
-			child1, child2 := cachedCrossover(parent1, parent2, cache)
-			child1 = cachedMutate(child1, *mutationRate, cache)
-			child2 = cachedMutate(child2, *mutationRate, cache)
-			newPopulation = append(newPopulation, child1, child2)
-		}
-
-		newPopulation = newPopulation[:*popSize]
-		evaluatePopulation(newPopulation, dm, cache)
-		population = newPopulation
-
-		if gen%50 == 0 || gen == *generations-1 {
-			fmt.Printf("Gen %d: Best = %.2f\n", gen, best.distance)
+	var best Route
+	if *numIslands > 1 {
+		islandCfg := islandsConfig{
+			numIslands:        *numIslands,
+			migrationInterval: *migrateInterval,
+			migrationSize:     *migrateSize,
+			topology:          Topology(*topology),
 		}
+		best = runIslands(numCities, dm, cfg, islandCfg, effectiveSeed)
+	} else {
+		best = runPopulation(numCities, dm, cfg, rng, nil, true)
 	}
 
 	fmt.Printf("\nBest route distance: %.2f\n", best.distance)
 	fmt.Printf("Execution time: %s\n", time.Since(startTime))
-}
\ No newline at end of file
+}
@@ -1,50 +1,29 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"math"
 	"math/rand"
-	"os"
-	"strconv"
-	"strings"
 	"time"
-)
 
-type Point struct {
-	x, y float64
-}
+	"github.com/IcaroOm/TSP_algoritimo_genetico_golang/localsearch"
+	"github.com/IcaroOm/TSP_algoritimo_genetico_golang/tsplib"
+)
 
 type Route struct {
 	path     []int
 	distance float64
 }
 
-type DistanceMatrix [][]float64
-
-func (dm DistanceMatrix) Distance(i, j int) float64 {
-	return dm[i][j]
-}
-
-func createDistanceMatrix(points []Point) DistanceMatrix {
-	n := len(points)
-	matrix := make(DistanceMatrix, n)
-	for i := range matrix {
-		matrix[i] = make([]float64, n)
-		for j := range matrix[i] {
-			matrix[i][j] = math.Hypot(points[i].x-points[j].x, points[i].y-points[j].y)
-		}
-	}
-	return matrix
-}
+type DistanceMatrix = tsplib.DistanceMatrix
 
-func randomRoute(numCities int) Route {
+func randomRoute(numCities int, rng *rand.Rand) Route {
 	path := make([]int, numCities)
 	for i := 0; i < numCities; i++ {
 		path[i] = i
 	}
-	rand.Shuffle(len(path), func(i, j int) {
+	rng.Shuffle(len(path), func(i, j int) {
 		path[i], path[j] = path[j], path[i]
 	})
 	return Route{path: path}
@@ -59,159 +38,164 @@ func calculateDistance(route Route, dm DistanceMatrix) float64 {
 	return total
 }
 
-func generateNeighbor(current Route) Route {
-	neighbor := Route{
-		path: make([]int, len(current.path)),
-	}
-	copy(neighbor.path, current.path)
-
-	a := rand.Intn(len(neighbor.path))
-	b := rand.Intn(len(neighbor.path))
-	if a > b {
-		a, b = b, a
-	}
-
-	for i := 0; i < (b-a+1)/2; i++ {
-		neighbor.path[a+i], neighbor.path[b-i] = neighbor.path[b-i], neighbor.path[a+i]
-	}
-
-	return neighbor
+// saConfig bundles the knobs simulatedAnnealing needs beyond the schedule
+// itself: how long to run, whether to polish accepted bests with local
+// search, the reheating trigger, and the neighborhood move mix.
+type saConfig struct {
+	initialTemp   float64
+	iterations    int
+	twoOpt        bool
+	lsStrategy    localsearch.Strategy
+	lsMaxPasses   int
+	lsSegLen      int
+	lsLKDepth     int
+	stagnation    int
+	reheatFactor  float64
+	neighborhoods neighborhoodProbs
 }
 
-func simulatedAnnealing(dm DistanceMatrix, initialTemp, coolingRate float64, iterations int) Route {
-	current := randomRoute(len(dm))
+func simulatedAnnealing(dm DistanceMatrix, cfg saConfig, schedule Schedule, rng *rand.Rand) Route {
+	current := randomRoute(len(dm), rng)
 	current.distance = calculateDistance(current, dm)
 
 	best := current
-	temp := initialTemp
+	temp := cfg.initialTemp
+	stagnantFor := 0
 
-	for i := 0; i < iterations; i++ {
-		neighbor := generateNeighbor(current)
+	for i := 0; i < cfg.iterations; i++ {
+		neighbor := generateNeighbor(current, cfg.neighborhoods, rng)
 		neighbor.distance = calculateDistance(neighbor, dm)
 
 		delta := neighbor.distance - current.distance
+		accepted := delta < 0 || math.Exp(-delta/temp) > rng.Float64()
 
-		if delta < 0 || math.Exp(-delta/temp) > rand.Float64() {
+		improvedBest := false
+		if accepted {
 			current = neighbor
-			if current.distance < best.distance {
+			if current.distance < best.distance-1e-9 {
 				best = current
+				improvedBest = true
+				if cfg.twoOpt {
+					refined := localsearch.Apply(
+						localsearch.Route{Path: best.path, Distance: best.distance},
+						dm, cfg.lsStrategy, cfg.lsMaxPasses, cfg.lsSegLen, cfg.lsLKDepth,
+					)
+					best = Route{path: refined.Path, distance: refined.Distance}
+					current = best
+				}
 			}
 		}
 
-		temp *= coolingRate
-
-		if i%1000 == 0 {
-			fmt.Printf("Iteration %d: Temp=%.2f Best=%.2f Current=%.2f\n",
-				i, temp, best.distance, current.distance)
+		if improvedBest {
+			stagnantFor = 0
+		} else {
+			stagnantFor++
 		}
-	}
 
-	return best
-}
+		temp = schedule.Next(temp, accepted)
 
-func readPointsFromFile(filename string) ([]Point, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	var dimension int
-	var points []Point
-	inCoordSection := false
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-
-		if strings.HasPrefix(line, "DIMENSION") {
-			parts := strings.Split(line, ":")
-			if len(parts) < 2 {
-				parts = strings.Split(line, " ")
-			}
-			if len(parts) < 2 {
-				return nil, fmt.Errorf("invalid DIMENSION line")
-			}
-			dimStr := strings.TrimSpace(parts[1])
-			dimension, err = strconv.Atoi(dimStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid dimension: %v", err)
-			}
-			points = make([]Point, dimension)
+		if cfg.stagnation > 0 && stagnantFor >= cfg.stagnation {
+			temp = cfg.initialTemp * cfg.reheatFactor
+			current = best
+			stagnantFor = 0
 		}
 
-		if strings.HasPrefix(line, "NODE_COORD_SECTION") {
-			inCoordSection = true
-			continue
-		}
-
-		if inCoordSection && strings.HasPrefix(line, "EOF") {
-			break
-		}
-
-		if inCoordSection {
-			parts := strings.Fields(line)
-			if len(parts) < 3 {
-				continue
-			}
-
-			idx, err := strconv.Atoi(parts[0])
-			if err != nil || idx < 1 || idx > dimension {
-				continue
-			}
-
-			x, err1 := strconv.ParseFloat(parts[1], 64)
-			y, err2 := strconv.ParseFloat(parts[2], 64)
-			if err1 == nil && err2 == nil {
-				points[idx-1] = Point{x: x, y: y}
-			}
-		}
-	}
-
-	for i, p := range points {
-		if p.x == 0 && p.y == 0 {
-			return nil, fmt.Errorf("missing coordinates for city %d", i+1)
+		if i%1000 == 0 {
+			fmt.Printf("Iteration %d: Temp=%.2f Best=%.2f Current=%.2f\n",
+				i, temp, best.distance, current.distance)
 		}
 	}
 
-	return points, nil
+	return best
 }
 
 func main() {
 	startTime := time.Now()
-	rand.Seed(time.Now().UnixNano())
 
 	var (
-		inputFile    = flag.String("input", "", "Input file in TSPLIB format")
-		initialTemp  = flag.Float64("temp", 100000.0, "Initial temperature")
-		coolingRate  = flag.Float64("cooling", 0.9999, "Cooling rate")
-		iterations   = flag.Int("iters", 500000, "Number of iterations")
+		inputFile     = flag.String("input", "", "Input file in TSPLIB format")
+		initialTemp   = flag.Float64("temp", 100000.0, "Initial temperature")
+		coolingRate   = flag.Float64("cooling", 0.9999, "Cooling rate (geometric schedule)")
+		iterations    = flag.Int("iters", 500000, "Number of iterations")
+		twoOpt        = flag.Bool("twoopt", false, "Refine newly accepted best tours with local search")
+		lsStrategy    = flag.String("ls-strategy", "all", "Local search strategy when -twoopt is set: none|2opt|oropt|lk|both|all")
+		lsMaxPasses   = flag.Int("ls-maxpasses", 2, "2-opt: maximum number of passes (stops early after a pass with no improvement)")
+		lsSegLen      = flag.Int("ls-seglen", 3, "Or-opt: length of the relocated city segment")
+		lsLKDepth     = flag.Int("ls-lkdepth", 5, "Lin-Kernighan: maximum chained-move depth")
+		seed          = flag.Int64("seed", 0, "RNG seed (0 = time-based)")
+		schedule      = flag.String("schedule", "geometric", "Cooling schedule: geometric|lundy-mees|adaptive")
+		lundyMeesBeta = flag.Float64("beta", 0.0005, "Lundy-Mees schedule: cooling coefficient")
+		target        = flag.Float64("target", 0.44, "Adaptive schedule: target acceptance rate")
+		window        = flag.Int("window", 100, "Adaptive schedule: sliding window of proposals over which acceptance rate is measured")
+		stagnation    = flag.Int("stagnation", 5000, "Reheat when best hasn't improved for this many iterations (0 disables reheating)")
+		reheat        = flag.Float64("reheat", 0.5, "Reheat temperature as a fraction of the initial temperature")
+		orOptProb     = flag.Float64("oropt-prob", 0.3, "Probability of proposing an Or-opt segment relocation move")
+		threeOptProb  = flag.Float64("threeopt-prob", 0.2, "Probability of proposing a 3-opt segment swap move")
 	)
 	flag.Parse()
 
-	var points []Point
-	var err error
+	strategy, err := localsearch.ParseStrategy(*lsStrategy)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	effectiveSeed := *seed
+	if effectiveSeed == 0 {
+		effectiveSeed = time.Now().UnixNano()
+	}
+	fmt.Printf("Using seed: %d\n", effectiveSeed)
+	rng := rand.New(rand.NewSource(effectiveSeed))
+
+	var instance *tsplib.TSPInstance
 
 	switch {
 	case *inputFile != "":
-		points, err = readPointsFromFile(*inputFile)
+		instance, err = tsplib.ReadInstance(*inputFile)
 		if err != nil {
 			fmt.Println("Error reading file:", err)
 			return
 		}
-		fmt.Printf("Loaded %d cities from %s\n", len(points), *inputFile)
+		if err := instance.Validate(); err != nil {
+			fmt.Println("Invalid TSPLIB instance:", err)
+			return
+		}
+		fmt.Printf("Loaded %d cities from %s\n", instance.Dimension, *inputFile)
 	default:
 		fmt.Println("No cities specified. Use -input or -random")
 		return
 	}
 
-	dm := createDistanceMatrix(points)
-	
+	dm := tsplib.BuildDistanceMatrix(instance)
+
+	var sched Schedule
+	switch *schedule {
+	case "lundy-mees":
+		sched = lundyMeesSchedule{beta: *lundyMeesBeta}
+	case "adaptive":
+		sched = newAdaptiveSchedule(*target, *window)
+	default:
+		sched = geometricSchedule{rate: *coolingRate}
+	}
+
+	cfg := saConfig{
+		initialTemp:  *initialTemp,
+		iterations:   *iterations,
+		twoOpt:       *twoOpt,
+		lsStrategy:   strategy,
+		lsMaxPasses:  *lsMaxPasses,
+		lsSegLen:     *lsSegLen,
+		lsLKDepth:    *lsLKDepth,
+		stagnation:   *stagnation,
+		reheatFactor: *reheat,
+		neighborhoods: neighborhoodProbs{
+			orOpt:    *orOptProb,
+			threeOpt: *threeOptProb,
+		},
+	}
+
 	fmt.Println("Running simulated annealing...")
-	best := simulatedAnnealing(dm, *initialTemp, *coolingRate, *iterations)
+	best := simulatedAnnealing(dm, cfg, sched, rng)
 
 	fmt.Printf("\nBest route distance: %.2f\n", best.distance)
 	fmt.Printf("Execution time: %s\n", time.Since(startTime))
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// neighborhoodProbs weights which move generateNeighbor picks on a given
+// step. orOpt and threeOpt are the probabilities of relocating a segment or
+// swapping two segments respectively; whatever probability remains goes to
+// the 2-opt reverse.
+type neighborhoodProbs struct {
+	orOpt    float64
+	threeOpt float64
+}
+
+func generateNeighbor(current Route, probs neighborhoodProbs, rng *rand.Rand) Route {
+	r := rng.Float64()
+	switch {
+	case r < probs.threeOpt:
+		return generateThreeOptNeighbor(current, rng)
+	case r < probs.threeOpt+probs.orOpt:
+		return generateOrOptNeighbor(current, rng)
+	default:
+		return generateTwoOptNeighbor(current, rng)
+	}
+}
+
+// generateTwoOptNeighbor reverses a random sub-segment of current's path.
+func generateTwoOptNeighbor(current Route, rng *rand.Rand) Route {
+	neighbor := Route{
+		path: make([]int, len(current.path)),
+	}
+	copy(neighbor.path, current.path)
+
+	a := rng.Intn(len(neighbor.path))
+	b := rng.Intn(len(neighbor.path))
+	if a > b {
+		a, b = b, a
+	}
+
+	for i := 0; i < (b-a+1)/2; i++ {
+		neighbor.path[a+i], neighbor.path[b-i] = neighbor.path[b-i], neighbor.path[a+i]
+	}
+
+	return neighbor
+}
+
+// generateOrOptNeighbor relocates a random segment of 1-3 consecutive
+// cities to a random position among the remaining cities; that position can
+// be the segment's original one, which leaves the tour unchanged.
+func generateOrOptNeighbor(current Route, rng *rand.Rand) Route {
+	n := len(current.path)
+	segLen := 1 + rng.Intn(3)
+	if segLen > n-2 {
+		segLen = 1
+	}
+	start := rng.Intn(n - segLen + 1)
+
+	seg := append([]int(nil), current.path[start:start+segLen]...)
+	rest := make([]int, 0, n-segLen)
+	rest = append(rest, current.path[:start]...)
+	rest = append(rest, current.path[start+segLen:]...)
+
+	insertAt := rng.Intn(len(rest) + 1)
+	path := make([]int, 0, n)
+	path = append(path, rest[:insertAt]...)
+	path = append(path, seg...)
+	path = append(path, rest[insertAt:]...)
+
+	return Route{path: path}
+}
+
+// generateThreeOptNeighbor picks three random cut points and swaps the two
+// interior segments without reversing either, one of the non-reversing
+// 3-opt reconnections.
+func generateThreeOptNeighbor(current Route, rng *rand.Rand) Route {
+	n := len(current.path)
+	if n < 6 {
+		return generateTwoOptNeighbor(current, rng)
+	}
+
+	points := make(map[int]bool, 3)
+	for len(points) < 3 {
+		points[1+rng.Intn(n-1)] = true
+	}
+	cuts := make([]int, 0, 3)
+	for p := range points {
+		cuts = append(cuts, p)
+	}
+	sort.Ints(cuts)
+	i, j, k := cuts[0], cuts[1], cuts[2]
+
+	path := make([]int, 0, n)
+	path = append(path, current.path[:i]...)
+	path = append(path, current.path[j:k]...)
+	path = append(path, current.path[i:j]...)
+	path = append(path, current.path[k:]...)
+
+	return Route{path: path}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func assertSamePath(t *testing.T, got, want []int) {
+	t.Helper()
+	gotSorted := append([]int(nil), got...)
+	wantSorted := append([]int(nil), want...)
+	sort.Ints(gotSorted)
+	sort.Ints(wantSorted)
+	if len(gotSorted) != len(wantSorted) {
+		t.Fatalf("neighbor has %d cities, want %d", len(gotSorted), len(wantSorted))
+	}
+	for i := range gotSorted {
+		if gotSorted[i] != wantSorted[i] {
+			t.Fatalf("neighbor path %v is not a permutation of %v", got, want)
+		}
+	}
+}
+
+func TestGenerateTwoOptNeighborPreservesCities(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	route := Route{path: []int{1, 2, 3, 4, 5, 6}}
+	for i := 0; i < 20; i++ {
+		neighbor := generateTwoOptNeighbor(route, rng)
+		assertSamePath(t, neighbor.path, route.path)
+	}
+}
+
+func TestGenerateOrOptNeighborPreservesCities(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	route := Route{path: []int{1, 2, 3, 4, 5, 6}}
+	for i := 0; i < 20; i++ {
+		neighbor := generateOrOptNeighbor(route, rng)
+		assertSamePath(t, neighbor.path, route.path)
+	}
+}
+
+func TestGenerateThreeOptNeighborPreservesCities(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	route := Route{path: []int{1, 2, 3, 4, 5, 6, 7, 8}}
+	for i := 0; i < 20; i++ {
+		neighbor := generateThreeOptNeighbor(route, rng)
+		assertSamePath(t, neighbor.path, route.path)
+	}
+}
+
+func TestGenerateThreeOptNeighborFallsBackBelowSixCities(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	route := Route{path: []int{1, 2, 3, 4}}
+	neighbor := generateThreeOptNeighbor(route, rng)
+	assertSamePath(t, neighbor.path, route.path)
+}
+
+func TestGenerateNeighborPreservesCitiesAcrossAllMoves(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	route := Route{path: []int{1, 2, 3, 4, 5, 6, 7, 8}}
+	probs := neighborhoodProbs{orOpt: 0.3, threeOpt: 0.3}
+	for i := 0; i < 50; i++ {
+		neighbor := generateNeighbor(route, probs, rng)
+		assertSamePath(t, neighbor.path, route.path)
+	}
+}
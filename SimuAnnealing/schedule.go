@@ -0,0 +1,78 @@
+package main
+
+// Schedule decides how the annealing temperature evolves between
+// iterations. Next is called once per iteration with the current
+// temperature and whether the last proposed move was accepted, and
+// returns the temperature to use for the next one.
+type Schedule interface {
+	Next(t float64, accepted bool) float64
+}
+
+// geometricSchedule is the original fixed-rate cooling: temp *= rate every
+// iteration regardless of acceptance.
+type geometricSchedule struct {
+	rate float64
+}
+
+func (s geometricSchedule) Next(t float64, accepted bool) float64 {
+	return t * s.rate
+}
+
+// lundyMeesSchedule cools more slowly as temp drops, which spends more
+// iterations near the temperatures where accept/reject decisions are most
+// informative instead of decaying geometrically all the way down.
+type lundyMeesSchedule struct {
+	beta float64
+}
+
+func (s lundyMeesSchedule) Next(t float64, accepted bool) float64 {
+	return t / (1 + s.beta*t)
+}
+
+// adaptiveSchedule tracks the acceptance rate over a sliding window of the
+// last window proposals and nudges the temperature up when too few moves
+// are being accepted and down when too many are, keeping it near target
+// instead of committing to a fixed decay curve.
+type adaptiveSchedule struct {
+	target   float64
+	window   int
+	history  []bool
+	pos      int
+	filled   int
+	accepted int
+}
+
+// newAdaptiveSchedule builds an adaptiveSchedule tracking acceptance over the
+// last window proposals; window is clamped to at least 1 so history always
+// has room for the most recent decision.
+func newAdaptiveSchedule(target float64, window int) *adaptiveSchedule {
+	if window < 1 {
+		window = 1
+	}
+	return &adaptiveSchedule{target: target, window: window, history: make([]bool, window)}
+}
+
+func (s *adaptiveSchedule) Next(t float64, accepted bool) float64 {
+	if s.filled == s.window {
+		if s.history[s.pos] {
+			s.accepted--
+		}
+	} else {
+		s.filled++
+	}
+	s.history[s.pos] = accepted
+	if accepted {
+		s.accepted++
+	}
+	s.pos = (s.pos + 1) % s.window
+
+	rate := float64(s.accepted) / float64(s.filled)
+	switch {
+	case rate > s.target:
+		return t * 0.99
+	case rate < s.target:
+		return t * 1.01
+	default:
+		return t
+	}
+}
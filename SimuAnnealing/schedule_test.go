@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestNewAdaptiveScheduleClampsWindow(t *testing.T) {
+	for _, window := range []int{0, -5} {
+		s := newAdaptiveSchedule(0.44, window)
+		if len(s.history) < 1 {
+			t.Fatalf("window=%d: history has no room for the most recent decision", window)
+		}
+		// Used to panic with "index out of range" on the very first call.
+		if got := s.Next(100, true); got <= 0 {
+			t.Fatalf("window=%d: Next returned non-positive temperature %v", window, got)
+		}
+	}
+}
+
+func TestAdaptiveScheduleRaisesTempWhenAcceptanceIsLow(t *testing.T) {
+	s := newAdaptiveSchedule(0.5, 4)
+	var temp float64 = 100
+	for i := 0; i < 4; i++ {
+		temp = s.Next(temp, false)
+	}
+	if temp <= 100 {
+		t.Fatalf("acceptance rate 0 < target 0.5: want temp raised above 100, got %v", temp)
+	}
+}
+
+func TestAdaptiveScheduleLowersTempWhenAcceptanceIsHigh(t *testing.T) {
+	s := newAdaptiveSchedule(0.5, 4)
+	var temp float64 = 100
+	for i := 0; i < 4; i++ {
+		temp = s.Next(temp, true)
+	}
+	if temp >= 100 {
+		t.Fatalf("acceptance rate 1 > target 0.5: want temp lowered below 100, got %v", temp)
+	}
+}
+
+func TestLundyMeesScheduleCools(t *testing.T) {
+	s := lundyMeesSchedule{beta: 0.001}
+	temp := 100.0
+	next := s.Next(temp, true)
+	if next >= temp {
+		t.Fatalf("lundyMeesSchedule should cool: got %v, want < %v", next, temp)
+	}
+}
+
+func TestGeometricScheduleAppliesRate(t *testing.T) {
+	s := geometricSchedule{rate: 0.9}
+	got := s.Next(100, true)
+	if want := 90.0; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package localsearch
+
+const lkNeighborListSize = 10
+
+// LinKernighan improves route with depth-bounded chained 2-opt moves. For
+// each city t1 with an active don't-look bit, it considers breaking the
+// edge (t1,t2) to its current tour successor and reconnecting through a
+// candidate t3 from t1's nearest neighbors: reversing the segment between
+// t2 and t3 replaces (t1,t2) and (t3,t4) [t4 = t3's tour successor] with
+// (t1,t3) and (t2,t4) — the only reconnection of those four endpoints that
+// keeps a single cycle instead of splitting it in two. Candidates are
+// tried in ascending distance from t1 so only moves with a chance at
+// positive gain are considered. Each step's reversal is applied eagerly,
+// so the accumulated gain is always the real distance saved so far; the
+// chain accepts as soon as that turns positive, otherwise it recurses from
+// the new t1-t2 edge up to depth k (forbidding reuse of an edge broken
+// earlier in the same chain, the sequential constraint) and undoes the
+// trial move on backtrack (reverseSegment is its own inverse over the same
+// index range). Cities with no improving chain get their don't-look bit
+// set so later passes skip them until one of their tour edges changes
+// again.
+func LinKernighan(route Route, dm DistanceMatrix, k int) Route {
+	n := len(route.Path)
+	if n < 4 || k < 1 {
+		return route
+	}
+
+	tour := append([]int(nil), route.Path...)
+	pos := make([]int, n)
+	for i, c := range tour {
+		pos[c] = i
+	}
+	neighbors := neighborLists(n, dm, lkNeighborListSize)
+	dontLook := make([]bool, n)
+
+	for {
+		improvedAny := false
+		for t1 := 0; t1 < n; t1++ {
+			if dontLook[t1] {
+				continue
+			}
+			if improveCity(t1, tour, pos, dm, neighbors, dontLook, k) {
+				improvedAny = true
+			} else {
+				dontLook[t1] = true
+			}
+		}
+		if !improvedAny {
+			break
+		}
+	}
+
+	return Route{Path: tour, Distance: tourLength(tour, dm)}
+}
+
+func improveCity(t1 int, tour, pos []int, dm DistanceMatrix, neighbors [][]int, dontLook []bool, k int) bool {
+	broken := make(map[[2]int]bool)
+	return chainMove(t1, 0, 1, k, tour, pos, dm, neighbors, broken, dontLook)
+}
+
+func edgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// chainMove tries to extend the chain anchored at t1 with one more 2-opt
+// exchange: it breaks t1's current successor edge (t1,t2) and reconnects
+// through a candidate t3 near t1, which (via reverseSegment) actually
+// yields edges (t1,t3) and (t2,t4) where t4 is t3's successor — removing
+// (t1,t2) and (t3,t4) from a cycle leaves two paths whose endpoints can
+// only be rejoined that way without splitting into two separate cycles.
+// Because every step applies its reversal immediately, cumGain is always
+// the real distance saved so far, so the move is accepted as soon as it
+// turns positive; otherwise the chain recurses up to depth maxDepth and
+// backs out via a second reverseSegment call over the same range when
+// nothing pans out.
+func chainMove(t1 int, cumGain float64, depth, maxDepth int, tour, pos []int, dm DistanceMatrix, neighbors [][]int, broken map[[2]int]bool, dontLook []bool) bool {
+	n := len(tour)
+	t2 := tour[(pos[t1]+1)%n]
+	d12 := dm.Distance(t1, t2)
+	edge12 := edgeKey(t1, t2)
+	if broken[edge12] {
+		return false
+	}
+
+	for _, t3 := range neighbors[t1] {
+		if t3 == t1 || t3 == t2 {
+			continue
+		}
+		d13 := dm.Distance(t1, t3)
+		if d13 >= d12 {
+			break
+		}
+
+		j := pos[t3]
+		t4 := tour[(j+1)%n]
+		if t4 == t1 {
+			continue
+		}
+		edge34 := edgeKey(t3, t4)
+		if broken[edge34] {
+			continue
+		}
+
+		stepGain := d12 + dm.Distance(t3, t4) - d13 - dm.Distance(t2, t4)
+
+		segStart, segEnd := pos[t2], j
+		reverseSegment(tour, pos, segStart, segEnd)
+
+		totalGain := cumGain + stepGain
+		if totalGain > 1e-9 {
+			dontLook[t1] = false
+			dontLook[t2] = false
+			dontLook[t3] = false
+			dontLook[t4] = false
+			return true
+		}
+
+		if depth < maxDepth {
+			broken[edge12] = true
+			broken[edge34] = true
+			if chainMove(t1, totalGain, depth+1, maxDepth, tour, pos, dm, neighbors, broken, dontLook) {
+				dontLook[t2] = false
+				dontLook[t4] = false
+				return true
+			}
+			delete(broken, edge12)
+			delete(broken, edge34)
+		}
+
+		reverseSegment(tour, pos, segStart, segEnd)
+	}
+	return false
+}
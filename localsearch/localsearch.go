@@ -0,0 +1,256 @@
+package localsearch
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DistanceMatrix is satisfied by any square distance matrix indexed by city id.
+type DistanceMatrix interface {
+	Distance(i, j int) float64
+}
+
+// Route is a closed tour: path is a permutation of city ids, the tour
+// returns to path[0] after the last city.
+type Route struct {
+	Path     []int
+	Distance float64
+}
+
+// Strategy selects which local search refinement to apply to a tour.
+type Strategy string
+
+const (
+	StrategyNone   Strategy = "none"
+	StrategyTwoOpt Strategy = "2opt"
+	StrategyOrOpt  Strategy = "oropt"
+	StrategyLK     Strategy = "lk"
+	StrategyBoth   Strategy = "both"
+	StrategyAll    Strategy = "all"
+)
+
+const defaultNeighborListSize = 10
+
+// ParseStrategy converts a CLI-facing strategy name (the same strings as the
+// Strategy constants: "none", "2opt", "oropt", "lk", "both", "all") into a
+// Strategy, so callers can expose -strategy flags without duplicating the
+// validation in every binary.
+func ParseStrategy(name string) (Strategy, error) {
+	switch s := Strategy(name); s {
+	case StrategyNone, StrategyTwoOpt, StrategyOrOpt, StrategyLK, StrategyBoth, StrategyAll:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown local search strategy %q (want none|2opt|oropt|lk|both|all)", name)
+	}
+}
+
+// Apply runs the requested refinement strategy against route and returns the
+// (possibly improved) result. lkDepth is only used by StrategyLK/StrategyAll.
+func Apply(route Route, dm DistanceMatrix, strategy Strategy, maxPasses, segLen, lkDepth int) Route {
+	switch strategy {
+	case StrategyTwoOpt:
+		return TwoOpt(route, dm, maxPasses)
+	case StrategyOrOpt:
+		return OrOpt(route, dm, segLen)
+	case StrategyLK:
+		return LinKernighan(route, dm, lkDepth)
+	case StrategyBoth:
+		return OrOpt(TwoOpt(route, dm, maxPasses), dm, segLen)
+	case StrategyAll:
+		refined := OrOpt(TwoOpt(route, dm, maxPasses), dm, segLen)
+		return LinKernighan(refined, dm, lkDepth)
+	default:
+		return route
+	}
+}
+
+// neighborLists returns, for every city, its k nearest neighbors sorted by
+// ascending distance, used to prune TwoOpt, OrOpt and LinKernighan to near
+// O(n*k) per pass instead of O(n^2).
+func neighborLists(n int, dm DistanceMatrix, k int) [][]int {
+	type candidate struct {
+		city int
+		dist float64
+	}
+
+	lists := make([][]int, n)
+	for city := 0; city < n; city++ {
+		candidates := make([]candidate, 0, n-1)
+		for other := 0; other < n; other++ {
+			if other == city {
+				continue
+			}
+			candidates = append(candidates, candidate{other, dm.Distance(city, other)})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].dist < candidates[j].dist
+		})
+		if k < len(candidates) {
+			candidates = candidates[:k]
+		}
+		neighbors := make([]int, len(candidates))
+		for i, c := range candidates {
+			neighbors[i] = c.city
+		}
+		lists[city] = neighbors
+	}
+	return lists
+}
+
+func tourLength(tour []int, dm DistanceMatrix) float64 {
+	total := 0.0
+	n := len(tour)
+	for i := 0; i < n; i++ {
+		total += dm.Distance(tour[i], tour[(i+1)%n])
+	}
+	return total
+}
+
+// TwoOpt repeatedly reverses sub-sequences of route while the 2-opt delta
+// d(i,j) + d(i+1,j+1) - d(i,i+1) - d(j,j+1) is negative, running at most
+// maxPasses passes and stopping early after the first pass with no
+// improvement. Candidate edges are pruned to each city's nearest neighbors
+// so a pass costs roughly O(n*k).
+func TwoOpt(route Route, dm DistanceMatrix, maxPasses int) Route {
+	n := len(route.Path)
+	if n < 4 {
+		return route
+	}
+
+	tour := append([]int(nil), route.Path...)
+	pos := make([]int, n)
+	for i, c := range tour {
+		pos[c] = i
+	}
+	neighbors := neighborLists(n, dm, defaultNeighborListSize)
+
+	for pass := 0; pass < maxPasses; pass++ {
+		improved := false
+		for i := 0; i < n; i++ {
+			a, aNext := tour[i], tour[(i+1)%n]
+			dA := dm.Distance(a, aNext)
+
+			for _, c := range neighbors[a] {
+				dAC := dm.Distance(a, c)
+				if dAC >= dA {
+					break
+				}
+				j := pos[c]
+				cNext := tour[(j+1)%n]
+				if c == aNext || cNext == a {
+					continue
+				}
+
+				delta := dAC + dm.Distance(aNext, cNext) - dA - dm.Distance(c, cNext)
+				if delta < -1e-9 {
+					reverseSegment(tour, pos, (i+1)%n, j)
+					improved = true
+					aNext = tour[(i+1)%n]
+					dA = dm.Distance(a, aNext)
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return Route{Path: tour, Distance: tourLength(tour, dm)}
+}
+
+func reverseSegment(tour, pos []int, i, j int) {
+	n := len(tour)
+	segLen := j - i
+	if segLen < 0 {
+		segLen += n
+	}
+	segLen++
+
+	for k := 0; k < segLen/2; k++ {
+		x := (i + k) % n
+		y := (j - k + n) % n
+		tour[x], tour[y] = tour[y], tour[x]
+		pos[tour[x]] = x
+		pos[tour[y]] = y
+	}
+}
+
+// OrOpt relocates segments of segLen consecutive cities next to one of their
+// nearest neighbors whenever doing so shortens the tour, iterating until no
+// relocation improves it.
+func OrOpt(route Route, dm DistanceMatrix, segLen int) Route {
+	n := len(route.Path)
+	if segLen < 1 || n < segLen+3 {
+		return route
+	}
+
+	tour := append([]int(nil), route.Path...)
+	neighbors := neighborLists(n, dm, defaultNeighborListSize)
+
+	improved := true
+	for improved {
+		improved = false
+		pos := make([]int, n)
+		for i, c := range tour {
+			pos[c] = i
+		}
+
+		for segStart := 0; segStart+segLen-1 < n; segStart++ {
+			segEnd := segStart + segLen - 1
+			prev := tour[(segStart-1+n)%n]
+			next := tour[(segEnd+1)%n]
+			segFirst, segLast := tour[segStart], tour[segEnd]
+
+			removed := dm.Distance(prev, segFirst) + dm.Distance(segLast, next) - dm.Distance(prev, next)
+			if removed <= 1e-9 {
+				continue
+			}
+
+			for _, c := range neighbors[segFirst] {
+				j := pos[c]
+				offset := (j - segStart + n) % n
+				if offset <= segLen || offset == n-1 {
+					continue
+				}
+				jNext := (j + 1) % n
+				cNext := tour[jNext]
+				if (jNext-segStart+n)%n < segLen {
+					continue
+				}
+
+				added := dm.Distance(c, segFirst) + dm.Distance(segLast, cNext) - dm.Distance(c, cNext)
+				if added < removed-1e-9 {
+					tour = relocateSegment(tour, segStart, segEnd, tour[j])
+					improved = true
+					break
+				}
+			}
+			if improved {
+				break
+			}
+		}
+	}
+
+	return Route{Path: tour, Distance: tourLength(tour, dm)}
+}
+
+func relocateSegment(tour []int, segStart, segEnd, afterCity int) []int {
+	seg := append([]int(nil), tour[segStart:segEnd+1]...)
+	rest := make([]int, 0, len(tour)-len(seg))
+	rest = append(rest, tour[:segStart]...)
+	rest = append(rest, tour[segEnd+1:]...)
+
+	insertAt := 0
+	for idx, c := range rest {
+		if c == afterCity {
+			insertAt = idx + 1
+			break
+		}
+	}
+
+	result := make([]int, 0, len(tour))
+	result = append(result, rest[:insertAt]...)
+	result = append(result, seg...)
+	result = append(result, rest[insertAt:]...)
+	return result
+}
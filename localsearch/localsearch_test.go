@@ -0,0 +1,112 @@
+package localsearch_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/IcaroOm/TSP_algoritimo_genetico_golang/localsearch"
+)
+
+// uniformMatrix gives every pair of distinct cities the same distance,
+// which is exactly the tie-heavy case that exposed the OrOpt wraparound
+// bug: with every delta equal, a broken exclusion window can pick an
+// insertion point inside the segment being moved without any distance
+// check ever flagging it as wrong.
+type uniformMatrix struct{}
+
+func (uniformMatrix) Distance(i, j int) float64 {
+	if i == j {
+		return 0
+	}
+	return 1
+}
+
+type pointMatrix [][2]float64
+
+func (m pointMatrix) Distance(i, j int) float64 {
+	dx := m[i][0] - m[j][0]
+	dy := m[i][1] - m[j][1]
+	return math.Hypot(dx, dy)
+}
+
+func tourLength(path []int, dm localsearch.DistanceMatrix) float64 {
+	total := 0.0
+	for i := range path {
+		total += dm.Distance(path[i], path[(i+1)%len(path)])
+	}
+	return total
+}
+
+// runWithTimeout fails the test instead of hanging the suite if fn doesn't
+// return, since the bug this file guards against was an infinite loop.
+func runWithTimeout(t *testing.T, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out, likely an infinite loop")
+	}
+}
+
+func TestOrOptTerminatesOnTiedDistances(t *testing.T) {
+	dm := uniformMatrix{}
+	route := localsearch.Route{Path: []int{0, 1, 2, 3, 4, 5}, Distance: tourLength([]int{0, 1, 2, 3, 4, 5}, dm)}
+
+	runWithTimeout(t, func() {
+		result := localsearch.OrOpt(route, dm, 2)
+		if result.Distance > route.Distance+1e-9 {
+			t.Fatalf("OrOpt increased tour length: %v -> %v", route.Distance, result.Distance)
+		}
+		if len(result.Path) != len(route.Path) {
+			t.Fatalf("OrOpt changed the number of cities: %d -> %d", len(route.Path), len(result.Path))
+		}
+	})
+}
+
+func TestOrOptSegmentAtBoundaryDoesNotCorrupt(t *testing.T) {
+	dm := uniformMatrix{}
+	path := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	route := localsearch.Route{Path: path, Distance: tourLength(path, dm)}
+
+	for _, segLen := range []int{1, 2, 3} {
+		runWithTimeout(t, func() {
+			result := localsearch.OrOpt(route, dm, segLen)
+			seen := make(map[int]bool, len(path))
+			for _, c := range result.Path {
+				if seen[c] {
+					t.Fatalf("OrOpt(segLen=%d) produced a duplicate city %d", segLen, c)
+				}
+				seen[c] = true
+			}
+			if len(seen) != len(path) {
+				t.Fatalf("OrOpt(segLen=%d) dropped a city: got %v", segLen, result.Path)
+			}
+		})
+	}
+}
+
+func TestLinKernighanNeverWorsensTour(t *testing.T) {
+	dm := pointMatrix{
+		{0, 0}, {10, 0}, {20, 5}, {15, 15},
+		{5, 20}, {0, 15}, {2, 8}, {18, 10},
+	}
+	path := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	route := localsearch.Route{Path: path, Distance: tourLength(path, dm)}
+
+	runWithTimeout(t, func() {
+		result := localsearch.LinKernighan(route, dm, 5)
+		if result.Distance > route.Distance+1e-6 {
+			t.Fatalf("LinKernighan worsened the tour: %v -> %v", route.Distance, result.Distance)
+		}
+		if math.Abs(result.Distance-tourLength(result.Path, dm)) > 1e-6 {
+			t.Fatalf("LinKernighan returned a Distance inconsistent with its Path: reported %v, actual %v",
+				result.Distance, tourLength(result.Path, dm))
+		}
+	})
+}
@@ -0,0 +1,306 @@
+package tsplib
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Point struct {
+	X, Y float64
+}
+
+type EdgeWeightType string
+
+const (
+	EUC2D    EdgeWeightType = "EUC_2D"
+	CEIL2D   EdgeWeightType = "CEIL_2D"
+	ATT      EdgeWeightType = "ATT"
+	GEO      EdgeWeightType = "GEO"
+	MAN2D    EdgeWeightType = "MAN_2D"
+	MAX2D    EdgeWeightType = "MAX_2D"
+	EXPLICIT EdgeWeightType = "EXPLICIT"
+)
+
+type EdgeWeightFormat string
+
+const (
+	FullMatrix   EdgeWeightFormat = "FULL_MATRIX"
+	UpperRow     EdgeWeightFormat = "UPPER_ROW"
+	LowerDiagRow EdgeWeightFormat = "LOWER_DIAG_ROW"
+	UpperDiagRow EdgeWeightFormat = "UPPER_DIAG_ROW"
+)
+
+// TSPInstance holds everything read from a TSPLIB file needed to build a
+// distance matrix, whatever EDGE_WEIGHT_TYPE it declares.
+type TSPInstance struct {
+	Dimension        int
+	EdgeWeightType   EdgeWeightType
+	EdgeWeightFormat EdgeWeightFormat
+	Points           []Point
+	Weights          [][]float64
+
+	// seenPoints tracks which indices of Points were actually set by
+	// ReadInstance's NODE_COORD_SECTION parsing, so Validate can tell a
+	// missing coordinate apart from a city genuinely sitting at (0,0).
+	// Left nil for instances built directly (e.g. in tests), in which case
+	// Validate skips the per-city check.
+	seenPoints []bool
+}
+
+type DistanceMatrix [][]float64
+
+func (dm DistanceMatrix) Distance(i, j int) float64 {
+	return dm[i][j]
+}
+
+// ReadInstance parses a TSPLIB file, following NODE_COORD_SECTION for
+// coordinate-based instances or EDGE_WEIGHT_SECTION for EXPLICIT ones.
+func ReadInstance(filename string) (*TSPInstance, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	instance := &TSPInstance{EdgeWeightType: EUC2D}
+	scanner := bufio.NewScanner(file)
+	section := ""
+	var weightNums []float64
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "EOF" {
+			break
+		}
+
+		if key, value, ok := splitHeader(line); ok {
+			switch key {
+			case "DIMENSION":
+				instance.Dimension, _ = strconv.Atoi(value)
+				instance.Points = make([]Point, instance.Dimension)
+				instance.seenPoints = make([]bool, instance.Dimension)
+			case "EDGE_WEIGHT_TYPE":
+				instance.EdgeWeightType = EdgeWeightType(value)
+			case "EDGE_WEIGHT_FORMAT":
+				instance.EdgeWeightFormat = EdgeWeightFormat(value)
+			}
+			continue
+		}
+
+		switch line {
+		case "NODE_COORD_SECTION":
+			section = line
+			continue
+		case "EDGE_WEIGHT_SECTION":
+			section = line
+			continue
+		case "DISPLAY_DATA_SECTION":
+			section = line
+			continue
+		}
+
+		switch section {
+		case "NODE_COORD_SECTION":
+			parts := strings.Fields(line)
+			if len(parts) < 3 {
+				continue
+			}
+			idx, err := strconv.Atoi(parts[0])
+			if err != nil || idx < 1 || idx > instance.Dimension {
+				continue
+			}
+			x, _ := strconv.ParseFloat(parts[1], 64)
+			y, _ := strconv.ParseFloat(parts[2], 64)
+			instance.Points[idx-1] = Point{x, y}
+			instance.seenPoints[idx-1] = true
+		case "EDGE_WEIGHT_SECTION":
+			for _, tok := range strings.Fields(line) {
+				w, err := strconv.ParseFloat(tok, 64)
+				if err == nil {
+					weightNums = append(weightNums, w)
+				}
+			}
+		}
+	}
+
+	if instance.EdgeWeightType == EXPLICIT && len(weightNums) > 0 {
+		instance.Weights = expandWeights(weightNums, instance.Dimension, instance.EdgeWeightFormat)
+	}
+
+	return instance, nil
+}
+
+func splitHeader(line string) (key, value string, ok bool) {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	key = strings.TrimSpace(parts[0])
+	value = strings.TrimSpace(parts[1])
+	switch key {
+	case "DIMENSION", "EDGE_WEIGHT_TYPE", "EDGE_WEIGHT_FORMAT":
+		return key, value, true
+	default:
+		return "", "", false
+	}
+}
+
+func expandWeights(nums []float64, n int, format EdgeWeightFormat) [][]float64 {
+	w := make([][]float64, n)
+	for i := range w {
+		w[i] = make([]float64, n)
+	}
+
+	pos := 0
+	next := func() float64 {
+		if pos >= len(nums) {
+			return 0
+		}
+		v := nums[pos]
+		pos++
+		return v
+	}
+
+	switch format {
+	case UpperRow:
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				v := next()
+				w[i][j], w[j][i] = v, v
+			}
+		}
+	case LowerDiagRow:
+		for i := 0; i < n; i++ {
+			for j := 0; j <= i; j++ {
+				v := next()
+				w[i][j], w[j][i] = v, v
+			}
+		}
+	case UpperDiagRow:
+		for i := 0; i < n; i++ {
+			for j := i; j < n; j++ {
+				v := next()
+				w[i][j], w[j][i] = v, v
+			}
+		}
+	default: // FULL_MATRIX
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				w[i][j] = next()
+			}
+		}
+	}
+
+	return w
+}
+
+// BuildDistanceMatrix computes every pairwise distance using the formula
+// implied by instance.EdgeWeightType.
+func BuildDistanceMatrix(instance *TSPInstance) DistanceMatrix {
+	n := instance.Dimension
+	dm := make(DistanceMatrix, n)
+	for i := range dm {
+		dm[i] = make([]float64, n)
+	}
+
+	if instance.EdgeWeightType == EXPLICIT {
+		for i := 0; i < n; i++ {
+			copy(dm[i], instance.Weights[i])
+		}
+		return dm
+	}
+
+	distFn := distanceFuncFor(instance.EdgeWeightType)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i != j {
+				dm[i][j] = distFn(instance.Points[i], instance.Points[j])
+			}
+		}
+	}
+	return dm
+}
+
+func distanceFuncFor(t EdgeWeightType) func(a, b Point) float64 {
+	switch t {
+	case CEIL2D:
+		return ceil2D
+	case ATT:
+		return att
+	case GEO:
+		return geo
+	case MAN2D:
+		return man2D
+	case MAX2D:
+		return max2D
+	default: // EUC_2D
+		return euc2D
+	}
+}
+
+func euc2D(a, b Point) float64 {
+	return math.Round(math.Hypot(a.X-b.X, a.Y-b.Y))
+}
+
+func ceil2D(a, b Point) float64 {
+	return math.Ceil(math.Hypot(a.X-b.X, a.Y-b.Y))
+}
+
+func att(a, b Point) float64 {
+	r := math.Sqrt(((a.X-b.X)*(a.X-b.X) + (a.Y-b.Y)*(a.Y-b.Y)) / 10.0)
+	return math.Ceil(r)
+}
+
+func man2D(a, b Point) float64 {
+	return math.Round(math.Abs(a.X-b.X) + math.Abs(a.Y-b.Y))
+}
+
+func max2D(a, b Point) float64 {
+	return math.Round(math.Max(math.Abs(a.X-b.X), math.Abs(a.Y-b.Y)))
+}
+
+const geoEarthRadius = 6378.388
+
+func toRadians(coord float64) float64 {
+	deg := math.Trunc(coord)
+	min := coord - deg
+	return math.Pi * (deg + 5.0*min/3.0) / 180.0
+}
+
+func geo(a, b Point) float64 {
+	latA, lonA := toRadians(a.X), toRadians(a.Y)
+	latB, lonB := toRadians(b.X), toRadians(b.Y)
+
+	q1 := math.Cos(lonA - lonB)
+	q2 := math.Cos(latA - latB)
+	q3 := math.Cos(latA + latB)
+
+	return math.Trunc(geoEarthRadius*math.Acos(0.5*((1+q1)*q2-(1-q1)*q3)) + 1.0)
+}
+
+// Validate reports an error describing what part of the instance could not
+// be used to build a distance matrix, or nil if it looks usable.
+func (instance *TSPInstance) Validate() error {
+	if instance.Dimension <= 0 {
+		return fmt.Errorf("missing or invalid DIMENSION")
+	}
+	if instance.EdgeWeightType == EXPLICIT {
+		if instance.Weights == nil {
+			return fmt.Errorf("EXPLICIT instance missing EDGE_WEIGHT_SECTION")
+		}
+		return nil
+	}
+	for i, seen := range instance.seenPoints {
+		if !seen {
+			return fmt.Errorf("missing coordinates for city %d", i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package tsplib
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func buildInstance(weightType EdgeWeightType, points []Point) *TSPInstance {
+	return &TSPInstance{
+		Dimension:      len(points),
+		EdgeWeightType: weightType,
+		Points:         points,
+	}
+}
+
+func writeTSPLIBFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "instance.tsp")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+	return path
+}
+
+func TestDistanceFormulas(t *testing.T) {
+	cases := []struct {
+		name     string
+		weight   EdgeWeightType
+		a, b     Point
+		expected float64
+	}{
+		{"EUC_2D", EUC2D, Point{X: 0, Y: 0}, Point{X: 3, Y: 4}, 5},
+		{"CEIL_2D", CEIL2D, Point{X: 0, Y: 0}, Point{X: 3, Y: 3}, 5},
+		{"MAN_2D", MAN2D, Point{X: 0, Y: 0}, Point{X: 3, Y: 4}, 7},
+		{"MAX_2D", MAX2D, Point{X: 0, Y: 0}, Point{X: 3, Y: 4}, 4},
+		{"ATT", ATT, Point{X: 0, Y: 0}, Point{X: 30, Y: 40}, 16},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := buildInstance(tc.weight, []Point{tc.a, tc.b})
+			dm := BuildDistanceMatrix(instance)
+			if dm[0][1] != tc.expected {
+				t.Errorf("%s: got %v, want %v", tc.name, dm[0][1], tc.expected)
+			}
+			if dm[0][1] != dm[1][0] {
+				t.Errorf("%s: distance not symmetric: %v vs %v", tc.name, dm[0][1], dm[1][0])
+			}
+		})
+	}
+}
+
+func TestGeoDistanceSymmetric(t *testing.T) {
+	// Coordinates in TSPLIB's DDD.MM format (degrees.minutes).
+	a := Point{X: 38.24, Y: 20.42}
+	b := Point{X: 39.57, Y: 26.15}
+
+	instance := buildInstance(GEO, []Point{a, b})
+	dm := BuildDistanceMatrix(instance)
+
+	if dm[0][1] != dm[1][0] {
+		t.Errorf("GEO distance not symmetric: %v vs %v", dm[0][1], dm[1][0])
+	}
+	if dm[0][1] <= 0 {
+		t.Errorf("GEO distance between distinct points should be positive, got %v", dm[0][1])
+	}
+}
+
+func TestExplicitWeightsUsedVerbatim(t *testing.T) {
+	instance := &TSPInstance{
+		Dimension:      3,
+		EdgeWeightType: EXPLICIT,
+		Weights: [][]float64{
+			{0, 1, 2},
+			{1, 0, 3},
+			{2, 3, 0},
+		},
+	}
+
+	dm := BuildDistanceMatrix(instance)
+	if dm.Distance(0, 2) != 2 {
+		t.Errorf("EXPLICIT: got %v, want 2", dm.Distance(0, 2))
+	}
+	if dm.Distance(1, 2) != 3 {
+		t.Errorf("EXPLICIT: got %v, want 3", dm.Distance(1, 2))
+	}
+}
+
+// TestReadInstanceExplicitFormats exercises expandWeights through
+// ReadInstance for the UPPER_ROW, LOWER_DIAG_ROW and UPPER_DIAG_ROW
+// formats (FULL_MATRIX is not among the cases), since a weight
+// matrix built directly (as in TestExplicitWeightsUsedVerbatim) never goes
+// through the row-decoding logic.
+func TestReadInstanceExplicitFormats(t *testing.T) {
+	cases := []struct {
+		name    string
+		format  EdgeWeightFormat
+		section string
+		want    [][]float64
+	}{
+		{
+			name:    "UPPER_ROW",
+			format:  UpperRow,
+			section: "1 2 3\n4 5\n6",
+			want: [][]float64{
+				{0, 1, 2, 3},
+				{1, 0, 4, 5},
+				{2, 4, 0, 6},
+				{3, 5, 6, 0},
+			},
+		},
+		{
+			name:    "LOWER_DIAG_ROW",
+			format:  LowerDiagRow,
+			section: "0\n1 0\n2 3 0",
+			want: [][]float64{
+				{0, 1, 2},
+				{1, 0, 3},
+				{2, 3, 0},
+			},
+		},
+		{
+			name:    "UPPER_DIAG_ROW",
+			format:  UpperDiagRow,
+			section: "0 1 2\n0 3\n0",
+			want: [][]float64{
+				{0, 1, 2},
+				{1, 0, 3},
+				{2, 3, 0},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := len(tc.want)
+			content := "NAME: fixture\n" +
+				"DIMENSION: " + strconv.Itoa(n) + "\n" +
+				"EDGE_WEIGHT_TYPE: EXPLICIT\n" +
+				"EDGE_WEIGHT_FORMAT: " + string(tc.format) + "\n" +
+				"EDGE_WEIGHT_SECTION\n" + tc.section + "\n" +
+				"EOF\n"
+			path := writeTSPLIBFile(t, content)
+
+			instance, err := ReadInstance(path)
+			if err != nil {
+				t.Fatalf("ReadInstance: %v", err)
+			}
+			if err := instance.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			dm := BuildDistanceMatrix(instance)
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					if dm[i][j] != tc.want[i][j] {
+						t.Errorf("dm[%d][%d] = %v, want %v", i, j, dm[i][j], tc.want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestValidateAcceptsOriginCoordinate guards the fix for a real bug: a city
+// legitimately sitting at (0,0) used to be indistinguishable from a city
+// whose coordinates were never parsed, since Validate compared against the
+// zero Point value instead of tracking which cities were actually read.
+func TestValidateAcceptsOriginCoordinate(t *testing.T) {
+	content := "NAME: fixture\n" +
+		"DIMENSION: 3\n" +
+		"EDGE_WEIGHT_TYPE: EUC_2D\n" +
+		"NODE_COORD_SECTION\n" +
+		"1 0 0\n" +
+		"2 10 0\n" +
+		"3 0 10\n" +
+		"EOF\n"
+	path := writeTSPLIBFile(t, content)
+
+	instance, err := ReadInstance(path)
+	if err != nil {
+		t.Fatalf("ReadInstance: %v", err)
+	}
+	if err := instance.Validate(); err != nil {
+		t.Fatalf("Validate rejected a city legitimately at the origin: %v", err)
+	}
+}
+
+func TestValidateRejectsMissingCoordinates(t *testing.T) {
+	content := "NAME: fixture\n" +
+		"DIMENSION: 3\n" +
+		"EDGE_WEIGHT_TYPE: EUC_2D\n" +
+		"NODE_COORD_SECTION\n" +
+		"1 10 0\n" +
+		"3 0 10\n" +
+		"EOF\n"
+	path := writeTSPLIBFile(t, content)
+
+	instance, err := ReadInstance(path)
+	if err != nil {
+		t.Fatalf("ReadInstance: %v", err)
+	}
+	if err := instance.Validate(); err == nil {
+		t.Fatal("Validate should reject city 2, whose coordinates were never parsed")
+	}
+}